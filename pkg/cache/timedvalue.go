@@ -0,0 +1,211 @@
+// Copyright 2020 Navibyte (https://navibyte.com). All rights reserved.
+// Use of this source code is governed by a MIT-style license, see the LICENSE.
+
+// Package cache provides small, dependency-free building blocks for caching
+// a single value with an expiry, shared across the packages that each used
+// to hand-roll their own version of this logic.
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrUpdateFailed is returned by TimedValue.Get when a refresh finished
+// without a value and without recording an error from Update. When Update
+// itself failed (and Relax has no previous value to fall back on), Get
+// returns Update's last error instead.
+var ErrUpdateFailed = errors.New("cache: update failed")
+
+const (
+	maxTriesForUpdate = 3
+	maxErrorsTotal    = 10
+	waitBeforeReset   = time.Hour
+)
+
+// TimedValue holds a single value produced by Update, refreshing it once it
+// has expired. Concurrent callers share one in-flight refresh per expiry
+// rather than each calling Update themselves.
+//
+// If StaleGrace is non-zero, a caller that arrives after the value has
+// expired but within the grace period gets the stale value immediately
+// while a refresh runs in the background; callers are only made to wait once
+// the grace period has also passed (or there is no value yet).
+type TimedValue[T any] struct {
+	// TTL is how long a successfully Updated value is considered fresh.
+	TTL time.Duration
+
+	// StaleGrace is how long an expired value may still be served while a
+	// background refresh is in flight. Zero disables stale-while-revalidate:
+	// every caller of Get blocks on a fresh Update once TTL has passed.
+	StaleGrace time.Duration
+
+	// Relax makes Get return the last good value instead of an error when
+	// Update is failing, as long as one was ever obtained.
+	Relax bool
+
+	// Update produces a fresh value. It's called with a background context,
+	// not a caller's Get context, since a refresh may outlive the Get call
+	// that triggered it.
+	Update func(ctx context.Context) (T, error)
+
+	mu        sync.Mutex
+	val       T
+	have      bool
+	expires   time.Time
+	updatedAt time.Time
+
+	fetcherID uint64
+	fresh     chan struct{} // non-nil while a refresh is running, closed when it finishes
+
+	errCountSinceReset int
+	lastErrTime        time.Time
+	lastErr            error
+}
+
+// Get returns the current value, refreshing it if needed. It blocks until a
+// value is available, ctx is done, or Update has failed enough times to give
+// up (see Relax).
+func (v *TimedValue[T]) Get(ctx context.Context) (T, error) {
+	v.mu.Lock()
+	now := time.Now()
+	if v.have {
+		if now.Before(v.expires) {
+			val := v.val
+			v.mu.Unlock()
+			return val, nil
+		}
+		if v.StaleGrace > 0 && now.Before(v.expires.Add(v.StaleGrace)) {
+			stale := v.val
+			v.startRefresherLocked()
+			v.mu.Unlock()
+			return stale, nil
+		}
+	}
+
+	fresh := v.startRefresherLocked()
+	v.mu.Unlock()
+
+	select {
+	case <-fresh:
+		v.mu.Lock()
+		defer v.mu.Unlock()
+		if v.have && (v.lastErr == nil || v.Relax) {
+			return v.val, nil
+		}
+		var zero T
+		if v.lastErr == nil {
+			return zero, ErrUpdateFailed
+		}
+		return zero, v.lastErr
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// Peek returns the current value, when it was last updated, and whether Get
+// would currently return it without blocking on a refresh - without itself
+// triggering one. It's meant for observability (e.g. reporting cache hits or
+// entry age) rather than normal reads; use Get for those.
+//
+// served is true both when the value is still within TTL and when it's
+// within the StaleGrace window (Get serves both cases immediately, the
+// latter while refreshing in the background); stale distinguishes which.
+func (v *TimedValue[T]) Peek() (value T, updatedAt time.Time, served bool, stale bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if !v.have {
+		var zero T
+		return zero, time.Time{}, false, false
+	}
+
+	now := time.Now()
+	if now.Before(v.expires) {
+		return v.val, v.updatedAt, true, false
+	}
+	if v.StaleGrace > 0 && now.Before(v.expires.Add(v.StaleGrace)) {
+		return v.val, v.updatedAt, true, true
+	}
+
+	var zero T
+	return zero, time.Time{}, false, false
+}
+
+// startRefresherLocked ensures a refresh goroutine is running and returns the
+// channel that closes once it (or whichever refresh is current by then)
+// finishes. Callers must hold v.mu.
+func (v *TimedValue[T]) startRefresherLocked() chan struct{} {
+	if v.fresh != nil {
+		return v.fresh
+	}
+
+	id := atomic.AddUint64(&v.fetcherID, 1)
+	fresh := make(chan struct{})
+	v.fresh = fresh
+
+	go v.refresh(id, fresh)
+
+	return fresh
+}
+
+// refresh retries Update a bounded number of times, then publishes the
+// result and closes fresh. If v's fetcherID no longer matches id, the result
+// is discarded and fresh is left open. A new goroutine is not spun up on
+// every error because startRefresherLocked only starts a refresh when none
+// is in flight.
+func (v *TimedValue[T]) refresh(id uint64, fresh chan struct{}) {
+	v.mu.Lock()
+	if v.errCountSinceReset >= maxErrorsTotal &&
+		time.Now().After(v.lastErrTime.Add(waitBeforeReset)) {
+
+		v.errCountSinceReset = 0
+		v.lastErr = nil
+	}
+	errCount := v.errCountSinceReset
+	v.mu.Unlock()
+
+	round := 0
+	for round < maxTriesForUpdate && errCount < maxErrorsTotal {
+		val, err := v.Update(context.Background())
+
+		v.mu.Lock()
+		if v.fetcherID != id {
+			// superseded by a newer refresh; discard this result
+			v.mu.Unlock()
+			return
+		}
+		if err != nil {
+			v.errCountSinceReset++
+			v.lastErr = err
+			v.lastErrTime = time.Now()
+			errCount = v.errCountSinceReset
+			v.mu.Unlock()
+			round++
+			continue
+		}
+
+		v.val = val
+		v.have = true
+		v.updatedAt = time.Now()
+		v.expires = v.updatedAt.Add(v.TTL)
+		v.errCountSinceReset = 0
+		v.lastErr = nil
+		v.fresh = nil
+		v.mu.Unlock()
+
+		close(fresh)
+		return
+	}
+
+	v.mu.Lock()
+	if v.fetcherID == id {
+		v.fresh = nil
+	}
+	v.mu.Unlock()
+
+	close(fresh)
+}
@@ -0,0 +1,223 @@
+// Copyright 2020 Navibyte (https://navibyte.com). All rights reserved.
+// Use of this source code is governed by a MIT-style license, see the LICENSE.
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTimedValueGetCachesWithinTTL(t *testing.T) {
+	var calls int32
+	v := &TimedValue[int]{
+		TTL: time.Hour,
+		Update: func(ctx context.Context) (int, error) {
+			atomic.AddInt32(&calls, 1)
+			return 42, nil
+		},
+	}
+
+	for i := 0; i < 3; i++ {
+		got, err := v.Get(context.Background())
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		if got != 42 {
+			t.Fatalf("Get() = %d, want 42", got)
+		}
+	}
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Fatalf("Update called %d times, want 1", got)
+	}
+}
+
+func TestTimedValueGetRefreshesAfterExpiry(t *testing.T) {
+	var calls int32
+	v := &TimedValue[int]{
+		TTL: time.Millisecond,
+		Update: func(ctx context.Context) (int, error) {
+			n := atomic.AddInt32(&calls, 1)
+			return int(n), nil
+		},
+	}
+
+	first, err := v.Get(context.Background())
+	if err != nil || first != 1 {
+		t.Fatalf("first Get() = (%d, %v), want (1, nil)", first, err)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+
+	second, err := v.Get(context.Background())
+	if err != nil {
+		t.Fatalf("second Get() error = %v", err)
+	}
+	if second <= first {
+		t.Fatalf("second Get() = %d, want a refreshed value greater than %d", second, first)
+	}
+}
+
+func TestTimedValueGetSharesOneUpdateAcrossConcurrentCallers(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+	v := &TimedValue[int]{
+		TTL: time.Hour,
+		Update: func(ctx context.Context) (int, error) {
+			atomic.AddInt32(&calls, 1)
+			<-release
+			return 7, nil
+		},
+	}
+
+	const callers = 10
+	var wg sync.WaitGroup
+	wg.Add(callers)
+	for i := 0; i < callers; i++ {
+		go func() {
+			defer wg.Done()
+			got, err := v.Get(context.Background())
+			if err != nil || got != 7 {
+				t.Errorf("Get() = (%d, %v), want (7, nil)", got, err)
+			}
+		}()
+	}
+
+	// give every goroutine a chance to block in Get before letting Update finish
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Fatalf("Update called %d times across %d concurrent callers, want 1", got, callers)
+	}
+}
+
+func TestTimedValueGetServesStaleWhileRevalidating(t *testing.T) {
+	var calls int32
+	blockSecondUpdate := make(chan struct{})
+	v := &TimedValue[int]{
+		TTL:        time.Millisecond,
+		StaleGrace: time.Hour,
+		Update: func(ctx context.Context) (int, error) {
+			n := atomic.AddInt32(&calls, 1)
+			if n == 2 {
+				<-blockSecondUpdate
+			}
+			return int(n), nil
+		},
+	}
+
+	if got, err := v.Get(context.Background()); err != nil || got != 1 {
+		t.Fatalf("initial Get() = (%d, %v), want (1, nil)", got, err)
+	}
+	time.Sleep(5 * time.Millisecond) // let the value expire into the grace window
+
+	start := time.Now()
+	got, err := v.Get(context.Background())
+	if err != nil {
+		t.Fatalf("stale Get() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 20*time.Millisecond {
+		t.Fatalf("stale Get() took %v, want it to return immediately without waiting on the in-flight refresh", elapsed)
+	}
+	if got != 1 {
+		t.Fatalf("stale Get() = %d, want the old value 1 while refresh is in flight", got)
+	}
+
+	close(blockSecondUpdate)
+}
+
+func TestTimedValueGetRelaxReturnsLastGoodValueOnFailure(t *testing.T) {
+	var fail int32
+	errUpdate := errors.New("boom")
+	v := &TimedValue[int]{
+		TTL:        time.Millisecond,
+		StaleGrace: 0,
+		Relax:      true,
+		Update: func(ctx context.Context) (int, error) {
+			if atomic.LoadInt32(&fail) != 0 {
+				return 0, errUpdate
+			}
+			return 9, nil
+		},
+	}
+
+	if got, err := v.Get(context.Background()); err != nil || got != 9 {
+		t.Fatalf("initial Get() = (%d, %v), want (9, nil)", got, err)
+	}
+
+	atomic.StoreInt32(&fail, 1)
+	time.Sleep(5 * time.Millisecond)
+
+	got, err := v.Get(context.Background())
+	if err != nil {
+		t.Fatalf("relaxed Get() error = %v, want nil", err)
+	}
+	if got != 9 {
+		t.Fatalf("relaxed Get() = %d, want the last good value 9", got)
+	}
+}
+
+func TestTimedValueGetReturnsErrorWithoutRelax(t *testing.T) {
+	errUpdate := errors.New("boom")
+	v := &TimedValue[int]{
+		TTL: time.Millisecond,
+		Update: func(ctx context.Context) (int, error) {
+			return 0, errUpdate
+		},
+	}
+
+	if _, err := v.Get(context.Background()); !errors.Is(err, errUpdate) {
+		t.Fatalf("Get() error = %v, want %v", err, errUpdate)
+	}
+}
+
+func TestTimedValueGetRespectsContextCancellation(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+	v := &TimedValue[int]{
+		TTL: time.Hour,
+		Update: func(ctx context.Context) (int, error) {
+			<-block
+			return 1, nil
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+
+	if _, err := v.Get(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Get() error = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestTimedValuePeek(t *testing.T) {
+	v := &TimedValue[int]{
+		TTL:        time.Millisecond,
+		StaleGrace: time.Hour,
+		Update: func(ctx context.Context) (int, error) {
+			return 5, nil
+		},
+	}
+
+	if _, _, served, stale := v.Peek(); served || stale {
+		t.Fatalf("Peek() before any value = (served=%v, stale=%v), want (false, false)", served, stale)
+	}
+
+	if _, err := v.Get(context.Background()); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if val, _, served, stale := v.Peek(); !served || stale || val != 5 {
+		t.Fatalf("Peek() after fresh Get = (%d, served=%v, stale=%v), want (5, true, false)", val, served, stale)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	if val, _, served, stale := v.Peek(); !served || !stale || val != 5 {
+		t.Fatalf("Peek() within stale grace = (%d, served=%v, stale=%v), want (5, true, true)", val, served, stale)
+	}
+}
@@ -0,0 +1,42 @@
+// Copyright 2020 Navibyte (https://navibyte.com). All rights reserved.
+// Use of this source code is governed by a MIT-style license, see the LICENSE.
+
+// Package backend abstracts where cached USGS earthquake collections live,
+// so the usgs package can share cached data across replicas (via Redis)
+// instead of every replica fetching independently, while still defaulting to
+// today's in-process behavior.
+package backend
+
+import (
+	"errors"
+	"time"
+
+	pb "github.com/navibyte/quake/api/v1"
+)
+
+// ErrNotFound is returned by Get when a key has no cached value yet.
+var ErrNotFound = errors.New("backend: key not found")
+
+// Backend stores fetched&parsed earthquake collections keyed by the caller's
+// cache key, and serializes fetch attempts for a given key.
+//
+// Lock/Unlock are held by a caller for the whole fetch+parse round trip that
+// refreshes a key, so implementations backed by a shared store (e.g. Redis)
+// should serialize across processes, not just within one.
+type Backend interface {
+	// Get returns the cached collection for key and when it expires.
+	// ErrNotFound is returned if key has never been Set.
+	Get(key string) (col *pb.EarthquakeCollection, expires time.Time, err error)
+
+	// Set stores col for key, expiring at expires.
+	Set(key string, col *pb.EarthquakeCollection, expires time.Time) error
+
+	// Stat returns the fetch and hit counters observed for key.
+	Stat(key string) (fetchCount, hitCount int)
+
+	// Lock serializes access to key across concurrent refreshers.
+	Lock(key string)
+
+	// Unlock releases a lock acquired by Lock.
+	Unlock(key string)
+}
@@ -0,0 +1,86 @@
+// Copyright 2020 Navibyte (https://navibyte.com). All rights reserved.
+// Use of this source code is governed by a MIT-style license, see the LICENSE.
+
+package backend
+
+import (
+	"sync"
+	"time"
+
+	pb "github.com/navibyte/quake/api/v1"
+)
+
+// Memory is the default Backend: an in-process map guarded by a per-key
+// mutex, matching the original cache behavior of this package.
+type Memory struct {
+	mu   sync.Mutex // guards the keys map itself, not individual entries
+	keys map[string]*memEntry
+}
+
+type memEntry struct {
+	serialMu sync.Mutex // held by Lock/Unlock across a caller's fetch+parse round trip
+
+	mu      sync.Mutex // guards the fields below
+	col     *pb.EarthquakeCollection
+	expires time.Time
+
+	fetchCount int
+	hitCount   int
+}
+
+// NewMemory returns a ready-to-use in-memory Backend.
+func NewMemory() *Memory {
+	return &Memory{keys: make(map[string]*memEntry)}
+}
+
+func (m *Memory) entry(key string) *memEntry {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.keys[key]
+	if !ok {
+		e = &memEntry{}
+		m.keys[key] = e
+	}
+	return e
+}
+
+// Get implements Backend.
+func (m *Memory) Get(key string) (*pb.EarthquakeCollection, time.Time, error) {
+	e := m.entry(key)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.col == nil {
+		return nil, time.Time{}, ErrNotFound
+	}
+	e.hitCount++
+	return e.col, e.expires, nil
+}
+
+// Set implements Backend.
+func (m *Memory) Set(key string, col *pb.EarthquakeCollection, expires time.Time) error {
+	e := m.entry(key)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.col = col
+	e.expires = expires
+	e.fetchCount++
+	return nil
+}
+
+// Stat implements Backend.
+func (m *Memory) Stat(key string) (fetchCount, hitCount int) {
+	e := m.entry(key)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.fetchCount, e.hitCount
+}
+
+// Lock implements Backend.
+func (m *Memory) Lock(key string) {
+	m.entry(key).serialMu.Lock()
+}
+
+// Unlock implements Backend.
+func (m *Memory) Unlock(key string) {
+	m.entry(key).serialMu.Unlock()
+}
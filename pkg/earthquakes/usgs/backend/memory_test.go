@@ -0,0 +1,88 @@
+// Copyright 2020 Navibyte (https://navibyte.com). All rights reserved.
+// Use of this source code is governed by a MIT-style license, see the LICENSE.
+
+package backend
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	pb "github.com/navibyte/quake/api/v1"
+)
+
+func TestMemoryGetMissingKeyReturnsErrNotFound(t *testing.T) {
+	m := NewMemory()
+	if _, _, err := m.Get("missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get(missing) error = %v, want %v", err, ErrNotFound)
+	}
+}
+
+func TestMemorySetThenGetRoundTrip(t *testing.T) {
+	m := NewMemory()
+	col := &pb.EarthquakeCollection{}
+	expires := time.Now().Add(time.Minute)
+
+	if err := m.Set("k", col, expires); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	got, gotExpires, err := m.Get("k")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got != col {
+		t.Fatalf("Get() col = %v, want %v", got, col)
+	}
+	if !gotExpires.Equal(expires) {
+		t.Fatalf("Get() expires = %v, want %v", gotExpires, expires)
+	}
+}
+
+func TestMemoryStatCountsFetchesAndHits(t *testing.T) {
+	m := NewMemory()
+	col := &pb.EarthquakeCollection{}
+
+	if err := m.Set("k", col, time.Now().Add(time.Minute)); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if _, _, err := m.Get("k"); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if _, _, err := m.Get("k"); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+
+	fetchCount, hitCount := m.Stat("k")
+	if fetchCount != 1 {
+		t.Errorf("fetchCount = %d, want 1", fetchCount)
+	}
+	if hitCount != 2 {
+		t.Errorf("hitCount = %d, want 2", hitCount)
+	}
+}
+
+func TestMemoryLockSerializesConcurrentCallers(t *testing.T) {
+	m := NewMemory()
+
+	m.Lock("k")
+	unlocked := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		m.Lock("k")
+		defer m.Unlock("k")
+		select {
+		case <-unlocked:
+		default:
+			t.Error("second Lock() acquired before the first Unlock()")
+		}
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	close(unlocked)
+	m.Unlock("k")
+	wg.Wait()
+}
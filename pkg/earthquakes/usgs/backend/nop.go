@@ -0,0 +1,60 @@
+// Copyright 2020 Navibyte (https://navibyte.com). All rights reserved.
+// Use of this source code is governed by a MIT-style license, see the LICENSE.
+
+package backend
+
+import (
+	"sync"
+	"time"
+
+	pb "github.com/navibyte/quake/api/v1"
+)
+
+// Nop never caches anything: every Get misses. Useful in tests that want to
+// exercise the fetch/parse path on every call without sharing state between
+// cases.
+type Nop struct {
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+// NewNop returns a Backend that never caches.
+func NewNop() *Nop {
+	return &Nop{locks: make(map[string]*sync.Mutex)}
+}
+
+// Get implements Backend.
+func (n *Nop) Get(key string) (*pb.EarthquakeCollection, time.Time, error) {
+	return nil, time.Time{}, ErrNotFound
+}
+
+// Set implements Backend.
+func (n *Nop) Set(key string, col *pb.EarthquakeCollection, expires time.Time) error {
+	return nil
+}
+
+// Stat implements Backend.
+func (n *Nop) Stat(key string) (fetchCount, hitCount int) {
+	return 0, 0
+}
+
+func (n *Nop) lockFor(key string) *sync.Mutex {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	l, ok := n.locks[key]
+	if !ok {
+		l = &sync.Mutex{}
+		n.locks[key] = l
+	}
+	return l
+}
+
+// Lock implements Backend.
+func (n *Nop) Lock(key string) {
+	n.lockFor(key).Lock()
+}
+
+// Unlock implements Backend.
+func (n *Nop) Unlock(key string) {
+	n.lockFor(key).Unlock()
+}
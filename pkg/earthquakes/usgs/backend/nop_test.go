@@ -0,0 +1,36 @@
+// Copyright 2020 Navibyte (https://navibyte.com). All rights reserved.
+// Use of this source code is governed by a MIT-style license, see the LICENSE.
+
+package backend
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	pb "github.com/navibyte/quake/api/v1"
+)
+
+func TestNopNeverCaches(t *testing.T) {
+	n := NewNop()
+	col := &pb.EarthquakeCollection{}
+
+	if err := n.Set("k", col, time.Now().Add(time.Minute)); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	if _, _, err := n.Get("k"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get() error = %v, want %v even right after Set()", err, ErrNotFound)
+	}
+
+	fetchCount, hitCount := n.Stat("k")
+	if fetchCount != 0 || hitCount != 0 {
+		t.Fatalf("Stat() = (%d, %d), want (0, 0)", fetchCount, hitCount)
+	}
+}
+
+func TestNopLockUnlockDoNotPanic(t *testing.T) {
+	n := NewNop()
+	n.Lock("k")
+	n.Unlock("k")
+}
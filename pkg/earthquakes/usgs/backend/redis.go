@@ -0,0 +1,198 @@
+// Copyright 2020 Navibyte (https://navibyte.com). All rights reserved.
+// Use of this source code is governed by a MIT-style license, see the LICENSE.
+
+package backend
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/navibyte/quake/api/v1"
+)
+
+// Redis stores protobuf-marshalled EarthquakeCollections in Redis with
+// TTL = expiry, so eviction of expired entries is handled by Redis itself
+// rather than by the usgs package. This lets multiple quake API replicas
+// share one cache instead of each hammering USGS independently.
+//
+// Locking is a SETNX-based spin lock: each Lock call sets the lock key to a
+// random token it owns, and Unlock only deletes the key if it still holds
+// that token (via unlockScript). That ownership check is what keeps a caller
+// whose lock key has since expired and been taken by someone else from
+// deleting the new owner's lock. Lock blocks until it acquires the key,
+// matching Memory and Nop: the lock key itself still carries a TTL
+// (lockTTL) so a replica that dies while holding it doesn't wedge every
+// other replica forever.
+type Redis struct {
+	client *redis.Client
+	prefix string
+
+	lockTTL  time.Duration
+	lockPoll time.Duration
+
+	mu         sync.Mutex
+	localLocks map[string]*sync.Mutex
+	tokens     map[string]string // key -> token held by this process, if any
+}
+
+// unlockScript deletes the lock key only if it still holds the token that
+// acquired it, so a caller that never acquired the lock (or whose lock has
+// since expired and been taken by someone else) can't release it out from
+// under its new owner.
+var unlockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// NewRedis returns a Backend backed by the given Redis client. keyPrefix is
+// prepended to every cache key to namespace this package's data.
+func NewRedis(client *redis.Client, keyPrefix string) *Redis {
+	return &Redis{
+		client:     client,
+		prefix:     keyPrefix,
+		lockTTL:    10 * time.Second,
+		lockPoll:   50 * time.Millisecond,
+		localLocks: make(map[string]*sync.Mutex),
+		tokens:     make(map[string]string),
+	}
+}
+
+// lockToken returns a random value unique enough to identify this Lock call
+// as the current owner of a lock key.
+func lockToken() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		// crypto/rand failing is exceptional; fall back to a value that's
+		// still unique per call rather than leaving the token empty
+		return fmt.Sprintf("%d-%p", time.Now().UnixNano(), &b)
+	}
+	return hex.EncodeToString(b[:])
+}
+
+func (r *Redis) dataKey(key string) string {
+	return r.prefix + "data:" + key
+}
+
+func (r *Redis) statKey(key string) string {
+	return r.prefix + "stat:" + key
+}
+
+func (r *Redis) lockKey(key string) string {
+	return r.prefix + "lock:" + key
+}
+
+type redisValue struct {
+	Expires time.Time
+	Data    []byte
+}
+
+// Get implements Backend.
+func (r *Redis) Get(key string) (*pb.EarthquakeCollection, time.Time, error) {
+	ctx := context.Background()
+	data, err := r.client.Get(ctx, r.dataKey(key)).Bytes()
+	if err == redis.Nil {
+		return nil, time.Time{}, ErrNotFound
+	}
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("backend: redis get: %w", err)
+	}
+
+	var col pb.EarthquakeCollection
+	if err := proto.Unmarshal(data, &col); err != nil {
+		return nil, time.Time{}, fmt.Errorf("backend: unmarshal cached collection: %w", err)
+	}
+
+	ttl, err := r.client.TTL(ctx, r.dataKey(key)).Result()
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("backend: redis ttl: %w", err)
+	}
+	r.client.Incr(ctx, r.statKey(key)+":hits")
+	return &col, time.Now().Add(ttl), nil
+}
+
+// Set implements Backend.
+func (r *Redis) Set(key string, col *pb.EarthquakeCollection, expires time.Time) error {
+	ctx := context.Background()
+	data, err := proto.Marshal(col)
+	if err != nil {
+		return fmt.Errorf("backend: marshal collection: %w", err)
+	}
+
+	ttl := time.Until(expires)
+	if ttl <= 0 {
+		ttl = time.Second
+	}
+	if err := r.client.Set(ctx, r.dataKey(key), data, ttl).Err(); err != nil {
+		return fmt.Errorf("backend: redis set: %w", err)
+	}
+	r.client.Incr(ctx, r.statKey(key)+":fetches")
+	return nil
+}
+
+// Stat implements Backend.
+func (r *Redis) Stat(key string) (fetchCount, hitCount int) {
+	ctx := context.Background()
+	fetchCount, _ = r.client.Get(ctx, r.statKey(key)+":fetches").Int()
+	hitCount, _ = r.client.Get(ctx, r.statKey(key)+":hits").Int()
+	return fetchCount, hitCount
+}
+
+// Lock implements Backend.
+//
+// It serializes across processes via SETNX, but also takes a local mutex so
+// that within one process a second caller blocks instead of spinning on
+// Redis round-trips for no reason. Lock blocks until it acquires the
+// distributed lock - giving up early would let the caller (updateList in
+// cache.go) proceed to fetch and Set unprotected, defeating the point of
+// having a shared lock at all. A caller that wants a bound on how long it
+// waits should use Get's ctx, not Lock.
+func (r *Redis) Lock(key string) {
+	r.mu.Lock()
+	l, ok := r.localLocks[key]
+	if !ok {
+		l = &sync.Mutex{}
+		r.localLocks[key] = l
+	}
+	r.mu.Unlock()
+	l.Lock()
+
+	token := lockToken()
+	ctx := context.Background()
+	for {
+		ok, err := r.client.SetNX(ctx, r.lockKey(key), token, r.lockTTL).Result()
+		if err == nil && ok {
+			r.mu.Lock()
+			r.tokens[key] = token
+			r.mu.Unlock()
+			return
+		}
+		time.Sleep(r.lockPoll)
+	}
+}
+
+// Unlock implements Backend.
+func (r *Redis) Unlock(key string) {
+	r.mu.Lock()
+	token, owned := r.tokens[key]
+	delete(r.tokens, key)
+	l := r.localLocks[key]
+	r.mu.Unlock()
+
+	if owned {
+		ctx := context.Background()
+		unlockScript.Run(ctx, r.client, []string{r.lockKey(key)}, token)
+	}
+
+	if l != nil {
+		l.Unlock()
+	}
+}
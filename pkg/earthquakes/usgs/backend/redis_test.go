@@ -0,0 +1,141 @@
+// Copyright 2020 Navibyte (https://navibyte.com). All rights reserved.
+// Use of this source code is governed by a MIT-style license, see the LICENSE.
+
+package backend
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+
+	pb "github.com/navibyte/quake/api/v1"
+)
+
+// newTestRedis returns a Redis backend talking to a fresh miniredis instance,
+// both cleaned up at the end of the test.
+func newTestRedis(t *testing.T) (*Redis, *miniredis.Miniredis) {
+	t.Helper()
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("miniredis.Run() error = %v", err)
+	}
+	t.Cleanup(mr.Close)
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	return NewRedis(client, "test:"), mr
+}
+
+func TestRedisGetMissingKeyReturnsErrNotFound(t *testing.T) {
+	r, _ := newTestRedis(t)
+	if _, _, err := r.Get("missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get(missing) error = %v, want %v", err, ErrNotFound)
+	}
+}
+
+func TestRedisSetThenGetRoundTrip(t *testing.T) {
+	r, _ := newTestRedis(t)
+	col := &pb.EarthquakeCollection{Features: []*pb.Earthquake{{Id: "eq1"}}}
+	expires := time.Now().Add(time.Minute)
+
+	if err := r.Set("k", col, expires); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	got, gotExpires, err := r.Get("k")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if len(got.GetFeatures()) != 1 || got.GetFeatures()[0].GetId() != "eq1" {
+		t.Fatalf("Get() col = %v, want round-tripped %v", got, col)
+	}
+	if d := gotExpires.Sub(expires); d < -2*time.Second || d > 2*time.Second {
+		t.Fatalf("Get() expires = %v, want close to %v", gotExpires, expires)
+	}
+}
+
+func TestRedisGetAfterTTLExpiryReturnsErrNotFound(t *testing.T) {
+	r, mr := newTestRedis(t)
+	col := &pb.EarthquakeCollection{}
+
+	if err := r.Set("k", col, time.Now().Add(time.Second)); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	mr.FastForward(2 * time.Second)
+
+	if _, _, err := r.Get("k"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get() after TTL expiry error = %v, want %v", err, ErrNotFound)
+	}
+}
+
+func TestRedisUnlockOnlyDeletesOwnToken(t *testing.T) {
+	r, _ := newTestRedis(t)
+
+	r.Lock("k")
+
+	// simulate the lock key having expired and a different replica having
+	// since acquired it
+	if err := r.client.Set(context.Background(), r.lockKey("k"), "someone-elses-token", 0).Err(); err != nil {
+		t.Fatalf("client.Set() error = %v", err)
+	}
+
+	r.Unlock("k")
+
+	val, err := r.client.Get(context.Background(), r.lockKey("k")).Result()
+	if err != nil {
+		t.Fatalf("client.Get() error = %v", err)
+	}
+	if val != "someone-elses-token" {
+		t.Fatalf("Unlock() deleted a lock key owned by another replica, got %q", val)
+	}
+}
+
+// TestRedisLockBlocksAcrossReplicasUntilAcquired exercises the cross-process
+// side of Lock: two Redis backends sharing one server stand in for two quake
+// replicas. r2 must not acquire the key while r1 holds it, and must not give
+// up - it has to keep polling until r1 releases it.
+func TestRedisLockBlocksAcrossReplicasUntilAcquired(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("miniredis.Run() error = %v", err)
+	}
+	t.Cleanup(mr.Close)
+
+	newReplica := func() *Redis {
+		client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+		t.Cleanup(func() { client.Close() })
+		return NewRedis(client, "test:")
+	}
+
+	r1, r2 := newReplica(), newReplica()
+	r2.lockPoll = time.Millisecond
+
+	r1.Lock("k")
+
+	acquired := make(chan struct{})
+	go func() {
+		r2.Lock("k")
+		close(acquired)
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("r2.Lock() acquired the key while r1 still held it")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	r1.Unlock("k")
+
+	select {
+	case <-acquired:
+		r2.Unlock("k")
+	case <-time.After(time.Second):
+		t.Fatal("r2.Lock() never acquired the key after r1.Unlock()")
+	}
+}
@@ -4,77 +4,161 @@
 package usgs
 
 import (
+	"context"
 	"errors"
+	"net/http"
 	"sync"
 	"time"
 
 	pb "github.com/navibyte/quake/api/v1"
+
+	"github.com/navibyte/quake/pkg/cache"
+	"github.com/navibyte/quake/pkg/earthquakes/usgs/backend"
+	"github.com/navibyte/quake/pkg/earthquakes/usgs/metrics"
 )
 
-// stat contains statistics about an cache entry
-type stat struct {
-	fetchCount int
-	hitCount   int
-}
+// ErrNotFound is returned when identified earthquake was not found
+var ErrNotFound = errors.New("earthquake not found")
 
-// entry for caching fetched&parsed responses
+// entry pairs the generic TimedValue for a (magnitude, past) key with the
+// backend it was created against, so that backend keeps being used for this
+// entry's refreshes even if UseBackend swaps in a different one later.
 type entry struct {
-	mu                 sync.Mutex
-	col                *pb.EarthquakeCollection
-	expires            time.Time
-	errCountSinceReset int
-	lastErrTime        time.Time
-	lastErr            error
-
-	stat
+	tv      *cache.TimedValue[*pb.EarthquakeCollection]
+	backend backend.Backend
 }
 
-const (
-	maxTriesForRequest = 3
-	maxErrorsTotal     = 10
-	waitBeforeReset    = time.Hour
-)
-
 var (
-	// cache entries identified by key generated by resolveCacheKey()
-	// (access to each entry is synchronized by a mutex for a key)
-	entries map[string]*entry
+	// entries hold one entry per (magnitude, past) key, created lazily on
+	// first access rather than enumerated up front.
+	entriesMu sync.Mutex
+	entries   = make(map[string]*entry)
 
-	// copies of entry stat (synchronized by one RW-mutex)
-	statMutex  sync.RWMutex
-	statCopies map[string]stat
+	// activeBackend stores the cached collections themselves. Defaults to an
+	// in-process map; change it with UseBackend. Guarded by backendMu since
+	// entryFor reads it from whichever goroutine first accesses a key.
+	backendMu     sync.Mutex
+	activeBackend backend.Backend = backend.NewMemory()
+
+	// staleGracePeriod is applied to entries created after it's set; see
+	// WithStaleGrace.
+	staleGracePeriod = 30 * time.Second
 )
 
-// ErrCacheFailure is returned on cache failures
-var ErrCacheFailure = errors.New("failure on caching earthquake collection")
+// UseBackend switches the storage backend for cached earthquake collections.
+// Call it during application setup, before any cache lookups happen - entries
+// already created keep using whichever backend was active when they were
+// created.
+func UseBackend(b backend.Backend) {
+	backendMu.Lock()
+	defer backendMu.Unlock()
+	activeBackend = b
+}
 
-// ErrNotFound is returned when identified earthquake was not found
-var ErrNotFound = errors.New("earthquake not found")
+// currentBackend returns the backend set by the most recent UseBackend call.
+func currentBackend() backend.Backend {
+	backendMu.Lock()
+	defer backendMu.Unlock()
+	return activeBackend
+}
 
-// init cache entries for all key combinations
-func init() {
-	// init entries
-	entries = make(map[string]*entry)
-	for _, magn := range pb.Magnitude_value {
-		for _, past := range pb.Past_value {
-			key := resolveCacheKey(pb.Magnitude(magn), pb.Past(past))
-			entries[key] = &entry{}
+// WithStaleGrace sets how long an expired entry may still be returned to
+// callers while it's refreshed in the background, instead of blocking them
+// on a fresh fetch. Passing 0 disables stale-while-revalidate. It only
+// affects entries created after the call.
+func WithStaleGrace(d time.Duration) {
+	staleGracePeriod = d
+}
+
+// entryFor returns the entry for (magnitude, past), creating it (pinned to
+// whichever backend is active right now, and backed by
+// fetch/ToEarthquakeCollection) on first access.
+func entryFor(magnitude pb.Magnitude, past pb.Past) *entry {
+	key := resolveCacheKey(magnitude, past)
+
+	entriesMu.Lock()
+	defer entriesMu.Unlock()
+	e, ok := entries[key]
+	if !ok {
+		b := currentBackend()
+		e = &entry{
+			backend: b,
+			tv: &cache.TimedValue[*pb.EarthquakeCollection]{
+				TTL:        resolveMaxAge(magnitude, past),
+				StaleGrace: staleGracePeriod,
+				Relax:      true,
+				Update:     updateList(b, key, magnitude, past),
+			},
 		}
+		entries[key] = e
+	}
+	return e
+}
+
+// updateList returns the TimedValue.Update func for (magnitude, past)
+// against b, the backend that was active when this entry was created: it
+// consults b first (another replica may have just refreshed this key), and
+// only fetches from USGS itself if b is also stale.
+func updateList(b backend.Backend, key string, magnitude pb.Magnitude, past pb.Past) func(ctx context.Context) (*pb.EarthquakeCollection, error) {
+	magn, pastStr := magnitude.String(), past.String()
+
+	return func(ctx context.Context) (*pb.EarthquakeCollection, error) {
+		if col, expires, err := b.Get(key); err == nil && time.Now().Before(expires) {
+			return col, nil
+		}
+
+		b.Lock(key)
+		defer b.Unlock(key)
+
+		// check again now that we hold the lock: another goroutine may have
+		// refreshed this key while we were waiting for it
+		if col, expires, err := b.Get(key); err == nil && time.Now().Before(expires) {
+			return col, nil
+		}
+
+		start := time.Now()
+		data, err := fetch(magnitude, past)
+		if err != nil {
+			metrics.FetchErrors.WithLabelValues(magn, pastStr, "fetch").Inc()
+			return nil, err
+		}
+		col, err := ToEarthquakeCollection(data, true)
+		if err != nil {
+			metrics.FetchErrors.WithLabelValues(magn, pastStr, "parse").Inc()
+			return nil, err
+		}
+		metrics.FetchDuration.WithLabelValues(magn, pastStr).Observe(time.Since(start).Seconds())
+
+		expires := time.Now().Add(resolveMaxAge(magnitude, past))
+		if err := b.Set(key, col, expires); err != nil {
+			return nil, err
+		}
+		metrics.CacheFetches.WithLabelValues(magn, pastStr).Inc()
+		for _, eq := range col.Features {
+			idCachePut(eq)
+		}
+		return col, nil
 	}
-	// init stat
-	statCopies = make(map[string]stat)
 }
 
 // cacheGetById returns a single earthquake (cached or fetched if no cache hit)
 func cacheGetById(id string) (*pb.Earthquake, error) {
+	return cacheGetByIdCtx(context.Background(), id)
+}
+
+// cacheGetByIdCtx is the context-aware variant of cacheGetById.
+func cacheGetByIdCtx(ctx context.Context, id string) (*pb.Earthquake, error) {
+	if eq, ok := idCacheGet(id); ok {
+		return eq, nil
+	}
 
 	// loop "hour", "day", "7days" and "30days" cached lists to find identified one
 	var lastErr error
 	for _, past := range pb.Past_value {
 		if pb.Past(past) != pb.Past_PAST_UNSPECIFIED {
 			// get full collection for given "past" value
-			col, err := cacheGetList(
-				pb.Magnitude_MAGNITUDE_ALL, pb.Past(past))
+			col, err := CacheGetListCtx(
+				ctx, pb.Magnitude_MAGNITUDE_ALL, pb.Past(past))
 			if err != nil {
 				lastErr = err
 			} else {
@@ -97,95 +181,65 @@ func cacheGetById(id string) (*pb.Earthquake, error) {
 // cacheGetList returns cached data from entry (or fetched data if no cache hit)
 func cacheGetList(magnitude pb.Magnitude, past pb.Past) (
 	*pb.EarthquakeCollection, error) {
+	return CacheGetListCtx(context.Background(), magnitude, past)
+}
 
-	// resolve cache key and entry
-	key := resolveCacheKey(magnitude, past)
-	entry := entries[key]
-	if entry == nil {
-		return nil, ErrCacheFailure
-	}
+// CacheGetListCtx returns cached data for (magnitude, past), refreshing it in
+// the background rather than blocking every caller behind the same round-trip.
+// See TimedValue.Get and TimedValue.StaleGrace for the exact semantics.
+func CacheGetListCtx(ctx context.Context, magnitude pb.Magnitude, past pb.Past) (
+	*pb.EarthquakeCollection, error) {
+	e := entryFor(magnitude, past)
+	magn, pastStr := magnitude.String(), past.String()
 
-	// synchronize access to an entry identified by the key
-	// (note that it's on purpose to acquire lock for all the time
-	// needed to access cache entry and to fecth/parse data if needed)
-	entry.mu.Lock()
-	defer entry.mu.Unlock()
-
-	// return cached data if available and not yet expired
-	if entry.col != nil {
-		if time.Now().After(entry.expires) {
-			entry.col = nil
-		} else {
-			// cache hit
-			entry.hitCount++
-			cacheSetStat(magnitude, past, entry.stat)
-			return entry.col, nil
-		}
+	// served covers both a fresh value and one within the stale-grace window:
+	// both are handed back immediately by Get below, without the caller
+	// blocking on a refresh, so both count as cache hits.
+	if _, updatedAt, served, _ := e.tv.Peek(); served {
+		metrics.CacheHits.WithLabelValues(magn, pastStr).Inc()
+		metrics.EntryAge.WithLabelValues(magn, pastStr).Set(time.Since(updatedAt).Seconds())
 	}
 
-	// if maximum number of errors occurred some time ago, reset error counters
-	if entry.errCountSinceReset >= maxErrorsTotal &&
-		time.Now().After(entry.lastErrTime.Add(waitBeforeReset)) {
+	return e.tv.Get(ctx)
+}
 
-		entry.errCountSinceReset = 0
-		entry.lastErr = nil
-	}
+// MetricsHandler exposes the usgs cache's Prometheus metrics, so the
+// existing gRPC/HTTP server can mount it at e.g. /metrics.
+func MetricsHandler() http.Handler {
+	return metrics.Handler()
+}
 
-	// could not get valid cache entry, so need to fetch data
-	// (trying to fetch&parse for few times before giving up)
-	round := 0
-	for round < maxTriesForRequest && entry.errCountSinceReset < maxErrorsTotal {
-		data, err := fetch(magnitude, past)
-		if err != nil { // fetch error
-			entry.errCountSinceReset++
-			entry.lastErr = err
-			entry.lastErrTime = time.Now()
-		} else {
-			// fetched data successfully, now trying to parse it
-			col, err := ToEarthquakeCollection(data, true)
-			if err != nil { // parse error
-				entry.errCountSinceReset++
-				entry.lastErr = err
-				entry.lastErrTime = time.Now()
-			} else {
-				// got valid response, store to the cache entry and return it
-				entry.col = col
-				entry.fetchCount++
-				cacheSetStat(magnitude, past, entry.stat)
-				entry.expires = time.Now().Add(resolveMaxAge(magnitude, past))
-				entry.errCountSinceReset = 0
-				entry.lastErr = nil
-				return col, nil
-			}
-		}
-		round++
-	}
+// cacheGetStat returns latest statistics about an entry, read from whichever
+// backend that entry is pinned to (or the currently active one, if the entry
+// hasn't been created yet).
+func cacheGetStat(magnitude pb.Magnitude, past pb.Past) stat {
+	key := resolveCacheKey(magnitude, past)
+
+	entriesMu.Lock()
+	e, ok := entries[key]
+	entriesMu.Unlock()
 
-	// did not succeed on getting valid response, return last error
-	if entry.lastErr == nil {
-		return nil, ErrCacheFailure
+	b := currentBackend()
+	if ok {
+		b = e.backend
 	}
-	return nil, entry.lastErr
+
+	fetchCount, hitCount := b.Stat(key)
+	return stat{fetchCount: fetchCount, hitCount: hitCount}
 }
 
-// cacheGetStat returns latest statistics about an entry
-func cacheGetStat(magnitude pb.Magnitude, past pb.Past) stat {
-	// when reading acquire a read lock for statistics
-	statMutex.RLock()
-	defer statMutex.RUnlock()
-	st, ok := statCopies[resolveCacheKey(magnitude, past)]
-	if !ok {
-		return stat{}
-	}
-	return st
+// cacheGetIDStat returns latest statistics about the per-id lookup cache
+// consulted by cacheGetById.
+func cacheGetIDStat() stat {
+	hitCount, missCount := idCacheStat()
+	return stat{hitCount: hitCount, missCount: missCount}
 }
 
-// cacheSetStat sets latest statistics fon an entry
-func cacheSetStat(magnitude pb.Magnitude, past pb.Past, st stat) {
-	// when writing acquire a regular lock for statistics
-	statMutex.Lock()
-	defer statMutex.Unlock()
-	statCopies[resolveCacheKey(magnitude, past)] = st
+// stat contains statistics about a cache entry
+type stat struct {
+	fetchCount int
+	hitCount   int
+	missCount  int
 }
 
 func resolveCacheKey(magnitude pb.Magnitude, past pb.Past) string {
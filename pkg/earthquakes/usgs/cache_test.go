@@ -0,0 +1,52 @@
+// Copyright 2020 Navibyte (https://navibyte.com). All rights reserved.
+// Use of this source code is governed by a MIT-style license, see the LICENSE.
+
+package usgs
+
+import (
+	"testing"
+	"time"
+
+	pb "github.com/navibyte/quake/api/v1"
+)
+
+func TestResolveCacheKey(t *testing.T) {
+	got := resolveCacheKey(pb.Magnitude_MAGNITUDE_ALL, pb.Past_PAST_DAY)
+	want := pb.Magnitude_MAGNITUDE_ALL.String() + "@" + pb.Past_PAST_DAY.String()
+	if got != want {
+		t.Fatalf("resolveCacheKey() = %q, want %q", got, want)
+	}
+}
+
+func TestResolveMaxAge(t *testing.T) {
+	cases := []struct {
+		past pb.Past
+		want time.Duration
+	}{
+		{pb.Past_PAST_HOUR, 3 * time.Minute},
+		{pb.Past_PAST_DAY, 5 * time.Minute},
+		{pb.Past_PAST_7DAYS, 10 * time.Minute},
+		{pb.Past_PAST_30DAYS, 15 * time.Minute},
+		{pb.Past_PAST_UNSPECIFIED, 15 * time.Minute},
+	}
+	for _, c := range cases {
+		if got := resolveMaxAge(pb.Magnitude_MAGNITUDE_ALL, c.past); got != c.want {
+			t.Errorf("resolveMaxAge(_, %v) = %v, want %v", c.past, got, c.want)
+		}
+	}
+}
+
+func TestWithStaleGraceSetsPackageDefault(t *testing.T) {
+	orig := staleGracePeriod
+	t.Cleanup(func() { staleGracePeriod = orig })
+
+	WithStaleGrace(2 * time.Minute)
+	if staleGracePeriod != 2*time.Minute {
+		t.Fatalf("staleGracePeriod = %v, want 2m after WithStaleGrace(2m)", staleGracePeriod)
+	}
+
+	WithStaleGrace(0)
+	if staleGracePeriod != 0 {
+		t.Fatalf("staleGracePeriod = %v, want 0 after WithStaleGrace(0)", staleGracePeriod)
+	}
+}
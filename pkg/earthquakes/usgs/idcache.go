@@ -0,0 +1,107 @@
+// Copyright 2020 Navibyte (https://navibyte.com). All rights reserved.
+// Use of this source code is governed by a MIT-style license, see the LICENSE.
+
+package usgs
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	pb "github.com/navibyte/quake/api/v1"
+)
+
+// idCacheEntry holds a single earthquake keyed by its id, so cacheGetById
+// doesn't have to walk every "ALL" collection on each lookup.
+type idCacheEntry struct {
+	eq      *pb.Earthquake
+	lastUse time.Time
+	expires time.Time
+}
+
+const defaultIDCacheMaxEntries = 5000
+
+// idCacheSoftTTL bounds how long a cached earthquake is served before
+// idCacheGet falls through to a live list lookup again. USGS routinely
+// revises magnitude/location for recent events as more data comes in, so an
+// id cache without a TTL would keep returning a stale snapshot forever
+// (until evicted purely by the LRU cap). A var, not a const, so tests can
+// shrink it instead of sleeping for the real duration.
+var idCacheSoftTTL = 3 * time.Minute
+
+var (
+	idCacheMu     sync.Mutex
+	idCache       = make(map[string]*idCacheEntry)
+	idCacheMax    = defaultIDCacheMaxEntries
+	idCacheHits   int
+	idCacheMisses int
+)
+
+// SetIDCacheSize sets the maximum number of earthquakes kept in the per-id
+// lookup cache. Once it's exceeded, sweepLocked prunes the least recently used
+// entries down to this size.
+func SetIDCacheSize(n int) {
+	idCacheMu.Lock()
+	defer idCacheMu.Unlock()
+	idCacheMax = n
+	sweepLocked()
+}
+
+// idCachePut stores eq, as a side effect of cacheGetList/CacheGetListCtx
+// parsing a fresh collection.
+func idCachePut(eq *pb.Earthquake) {
+	idCacheMu.Lock()
+	defer idCacheMu.Unlock()
+	now := time.Now()
+	idCache[eq.Id] = &idCacheEntry{eq: eq, lastUse: now, expires: now.Add(idCacheSoftTTL)}
+	if len(idCache) > idCacheMax {
+		sweepLocked()
+	}
+}
+
+// idCacheGet looks up id, bumping its recency on a hit. An entry past its
+// soft TTL counts as a miss, so the caller falls through to a list lookup
+// instead of serving a stale snapshot. That lookup puts the earthquake back
+// only if it fetches and parses a fresh collection from USGS.
+func idCacheGet(id string) (*pb.Earthquake, bool) {
+	idCacheMu.Lock()
+	defer idCacheMu.Unlock()
+	e, ok := idCache[id]
+	if !ok || time.Now().After(e.expires) {
+		idCacheMisses++
+		return nil, false
+	}
+	e.lastUse = time.Now()
+	idCacheHits++
+	return e.eq, true
+}
+
+// sweepLocked prunes idCache down to idCacheMax entries, keeping the most
+// recently used ones: entries are sorted by lastUse and everything older
+// than the Nth newest is dropped. Callers must hold idCacheMu.
+func sweepLocked() {
+	if len(idCache) <= idCacheMax {
+		return
+	}
+
+	type keyed struct {
+		id      string
+		lastUse time.Time
+	}
+	all := make([]keyed, 0, len(idCache))
+	for id, e := range idCache {
+		all = append(all, keyed{id, e.lastUse})
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].lastUse.After(all[j].lastUse) })
+
+	for _, k := range all[idCacheMax:] {
+		delete(idCache, k.id)
+	}
+}
+
+// idCacheStat returns the hit/miss counters for the per-id lookup cache.
+func idCacheStat() (hits, misses int) {
+	idCacheMu.Lock()
+	defer idCacheMu.Unlock()
+	return idCacheHits, idCacheMisses
+}
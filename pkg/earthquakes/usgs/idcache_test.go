@@ -0,0 +1,135 @@
+// Copyright 2020 Navibyte (https://navibyte.com). All rights reserved.
+// Use of this source code is governed by a MIT-style license, see the LICENSE.
+
+package usgs
+
+import (
+	"testing"
+	"time"
+
+	pb "github.com/navibyte/quake/api/v1"
+)
+
+// resetIDCache restores the package-level id cache state between tests.
+func resetIDCache(t *testing.T) {
+	t.Helper()
+	idCacheMu.Lock()
+	idCache = make(map[string]*idCacheEntry)
+	idCacheMax = defaultIDCacheMaxEntries
+	idCacheHits = 0
+	idCacheMisses = 0
+	idCacheMu.Unlock()
+
+	origTTL := idCacheSoftTTL
+	t.Cleanup(func() { idCacheSoftTTL = origTTL })
+}
+
+func TestIDCachePutThenGet(t *testing.T) {
+	resetIDCache(t)
+
+	eq := &pb.Earthquake{Id: "eq1"}
+	idCachePut(eq)
+
+	got, ok := idCacheGet("eq1")
+	if !ok {
+		t.Fatalf("idCacheGet(%q) ok = false, want true", eq.Id)
+	}
+	if got != eq {
+		t.Fatalf("idCacheGet(%q) = %v, want %v", eq.Id, got, eq)
+	}
+
+	hits, misses := idCacheStat()
+	if hits != 1 || misses != 0 {
+		t.Fatalf("idCacheStat() = (%d, %d), want (1, 0)", hits, misses)
+	}
+}
+
+func TestIDCacheGetMiss(t *testing.T) {
+	resetIDCache(t)
+
+	if _, ok := idCacheGet("missing"); ok {
+		t.Fatalf("idCacheGet(missing) ok = true, want false")
+	}
+
+	hits, misses := idCacheStat()
+	if hits != 0 || misses != 1 {
+		t.Fatalf("idCacheStat() = (%d, %d), want (0, 1)", hits, misses)
+	}
+}
+
+func TestIDCacheGetExpiresAfterSoftTTL(t *testing.T) {
+	resetIDCache(t)
+	idCacheSoftTTL = time.Millisecond
+
+	idCachePut(&pb.Earthquake{Id: "eq1"})
+	time.Sleep(5 * time.Millisecond)
+
+	if _, ok := idCacheGet("eq1"); ok {
+		t.Fatalf("idCacheGet(eq1) ok = true after soft TTL elapsed, want false")
+	}
+}
+
+func TestSweepLockedKeepsMostRecentlyUsed(t *testing.T) {
+	resetIDCache(t)
+
+	idCacheMu.Lock()
+	idCacheMax = 2
+	now := time.Now()
+	idCache["oldest"] = &idCacheEntry{eq: &pb.Earthquake{Id: "oldest"}, lastUse: now.Add(-2 * time.Minute), expires: now.Add(time.Hour)}
+	idCache["middle"] = &idCacheEntry{eq: &pb.Earthquake{Id: "middle"}, lastUse: now.Add(-1 * time.Minute), expires: now.Add(time.Hour)}
+	idCache["newest"] = &idCacheEntry{eq: &pb.Earthquake{Id: "newest"}, lastUse: now, expires: now.Add(time.Hour)}
+	sweepLocked()
+	_, keptOldest := idCache["oldest"]
+	_, keptMiddle := idCache["middle"]
+	_, keptNewest := idCache["newest"]
+	idCacheMu.Unlock()
+
+	if keptOldest {
+		t.Errorf("sweepLocked() kept %q, want it evicted as least recently used", "oldest")
+	}
+	if !keptMiddle || !keptNewest {
+		t.Errorf("sweepLocked() kept (middle=%v, newest=%v), want both true", keptMiddle, keptNewest)
+	}
+}
+
+func TestIDCachePutTriggersSweepOnceOverCap(t *testing.T) {
+	resetIDCache(t)
+
+	idCacheMu.Lock()
+	idCacheMax = 2
+	idCacheMu.Unlock()
+
+	idCachePut(&pb.Earthquake{Id: "first"})
+	idCachePut(&pb.Earthquake{Id: "second"})
+	idCachePut(&pb.Earthquake{Id: "third"})
+
+	idCacheMu.Lock()
+	n := len(idCache)
+	_, keptFirst := idCache["first"]
+	idCacheMu.Unlock()
+
+	if n != 2 {
+		t.Fatalf("len(idCache) = %d, want 2 after exceeding idCacheMax", n)
+	}
+	if keptFirst {
+		t.Errorf("idCache kept %q, want it swept as the oldest entry", "first")
+	}
+}
+
+func TestSetIDCacheSizeShrinksImmediately(t *testing.T) {
+	resetIDCache(t)
+
+	idCachePut(&pb.Earthquake{Id: "a"})
+	idCachePut(&pb.Earthquake{Id: "b"})
+	idCachePut(&pb.Earthquake{Id: "c"})
+
+	SetIDCacheSize(1)
+
+	idCacheMu.Lock()
+	n := len(idCache)
+	idCacheMu.Unlock()
+
+	if n != 1 {
+		t.Fatalf("len(idCache) = %d after SetIDCacheSize(1), want 1", n)
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright 2020 Navibyte (https://navibyte.com). All rights reserved.
+// Use of this source code is governed by a MIT-style license, see the LICENSE.
+
+// Package metrics registers Prometheus collectors for the usgs package's
+// cache behavior, so fetch/hit/error rates can be observed out-of-process.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Labels shared by every collector below: the requested magnitude and past
+// window, matching resolveCacheKey's components in the usgs package.
+var labelNames = []string{"magnitude", "past"}
+
+var (
+	// CacheHits counts cache lookups served without a USGS round trip.
+	CacheHits = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "quake_usgs_cache_hits_total",
+		Help: "Number of usgs cache lookups served from the cache.",
+	}, labelNames)
+
+	// CacheFetches counts successful fetch+parse round trips to USGS.
+	CacheFetches = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "quake_usgs_cache_fetches_total",
+		Help: "Number of successful fetch and parse round trips to USGS.",
+	}, labelNames)
+
+	// FetchErrors counts failed fetch or parse attempts, by stage.
+	FetchErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "quake_usgs_fetch_errors_total",
+		Help: "Number of failed USGS fetch or parse attempts, labeled by stage (fetch, parse).",
+	}, append(append([]string{}, labelNames...), "stage"))
+
+	// FetchDuration observes how long a successful fetch+parse round trip
+	// to USGS took.
+	FetchDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "quake_usgs_fetch_duration_seconds",
+		Help: "Duration of a successful USGS fetch and parse round trip.",
+	}, labelNames)
+
+	// EntryAge reports how old the currently cached collection is.
+	EntryAge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "quake_usgs_cache_entry_age_seconds",
+		Help: "Age of the collection currently cached for a key.",
+	}, labelNames)
+)
+
+func init() {
+	prometheus.MustRegister(CacheHits, CacheFetches, FetchErrors, FetchDuration, EntryAge)
+}
+
+// Handler exposes the registered collectors for Prometheus to scrape.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}